@@ -0,0 +1,62 @@
+package http
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// eventBuffer accumulates NDJSON-framed, already-serialized events until a
+// size or byte threshold is hit. It also keeps the backing publisher events
+// around so a failed flush can be handed back to the pipeline via
+// batch.RetryEvents.
+type eventBuffer struct {
+	data   []byte
+	events []int // indices into the batch's events slice
+}
+
+func newEventBuffer() *eventBuffer {
+	return &eventBuffer{}
+}
+
+func (b *eventBuffer) add(idx int, serialized []byte) {
+	b.data = append(b.data, serialized...)
+	b.data = append(b.data, '\n')
+	b.events = append(b.events, idx)
+}
+
+func (b *eventBuffer) full(maxCount, maxBytes int) bool {
+	return len(b.events) >= maxCount || len(b.data) >= maxBytes
+}
+
+func (b *eventBuffer) empty() bool {
+	return len(b.events) == 0
+}
+
+func (b *eventBuffer) reset() {
+	b.data = b.data[:0]
+	b.events = b.events[:0]
+}
+
+const (
+	backoffBase = 500 * time.Millisecond
+	backoffMax  = 30 * time.Second
+)
+
+// backoffDuration returns a full-jitter exponential backoff delay for the
+// given (0-indexed) retry attempt, capped at backoffMax.
+func backoffDuration(attempt int) time.Duration {
+	d := backoffBase << uint(attempt)
+	if d <= 0 || d > backoffMax {
+		d = backoffMax
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// isPermanentStatus reports whether an HTTP status code represents a
+// permanent failure (bad request, auth, etc.) that retrying will not fix.
+// 429 is deliberately excluded: rate limiting is transient and worth
+// retrying with backoff, not dropping.
+func isPermanentStatus(code int) bool {
+	return code >= http.StatusBadRequest && code < http.StatusInternalServerError && code != http.StatusTooManyRequests
+}
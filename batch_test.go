@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEventBufferAddFullReset(t *testing.T) {
+	b := newEventBuffer()
+	if !b.empty() {
+		t.Fatalf("new buffer should be empty")
+	}
+
+	b.add(0, []byte(`{"a":1}`))
+	b.add(1, []byte(`{"b":2}`))
+
+	if b.empty() {
+		t.Fatalf("buffer with events should not be empty")
+	}
+	if got, want := string(b.data), "{\"a\":1}\n{\"b\":2}\n"; got != want {
+		t.Fatalf("data = %q, want %q", got, want)
+	}
+	if got, want := len(b.events), 2; got != want {
+		t.Fatalf("len(events) = %d, want %d", got, want)
+	}
+
+	if b.full(3, 1<<20) {
+		t.Fatalf("buffer should not be full yet by count")
+	}
+	if !b.full(2, 1<<20) {
+		t.Fatalf("buffer should be full at maxCount=2")
+	}
+	if !b.full(100, len(b.data)) {
+		t.Fatalf("buffer should be full at maxBytes=len(data)")
+	}
+
+	b.reset()
+	if !b.empty() {
+		t.Fatalf("buffer should be empty after reset")
+	}
+	if len(b.data) != 0 {
+		t.Fatalf("data should be empty after reset, got %q", b.data)
+	}
+}
+
+func TestBackoffDurationCapsAndJitters(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		d := backoffDuration(attempt)
+		if d < 0 {
+			t.Fatalf("attempt %d: backoffDuration returned negative duration %v", attempt, d)
+		}
+		if d > backoffMax {
+			t.Fatalf("attempt %d: backoffDuration %v exceeds cap %v", attempt, d, backoffMax)
+		}
+	}
+}
+
+func TestBackoffDurationHighAttemptDoesNotOverflow(t *testing.T) {
+	// attempt large enough that backoffBase<<attempt overflows int64 and
+	// wraps to zero; the cap must still kick in rather than returning a
+	// nonsensical (or negative) duration.
+	d := backoffDuration(63)
+	if d <= 0 || d > backoffMax {
+		t.Fatalf("backoffDuration(63) = %v, want (0, %v]", d, backoffMax)
+	}
+}
+
+func TestIsPermanentStatus(t *testing.T) {
+	cases := []struct {
+		code      int
+		permanent bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, false}, // retryable, not permanent
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+	}
+
+	for _, c := range cases {
+		if got := isPermanentStatus(c.code); got != c.permanent {
+			t.Errorf("isPermanentStatus(%d) = %v, want %v", c.code, got, c.permanent)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bearerTokenSource supplies the bearer token to use on outgoing requests.
+// It is an alternative to basic auth: both use the Authorization header, so
+// when username is also set, basic auth replaces the bearer token. A
+// bearer_token_file is re-read
+// whenever its mtime changes, so rotating the token on disk doesn't
+// require restarting the beat.
+type bearerTokenSource struct {
+	static string
+	path   string
+
+	mu      sync.Mutex
+	cached  string
+	modTime time.Time
+}
+
+func newBearerTokenSource(c config) *bearerTokenSource {
+	return &bearerTokenSource{static: c.BearerToken, path: c.BearerTokenFile}
+}
+
+// token returns the current bearer token, or "" if none is configured.
+func (b *bearerTokenSource) token() string {
+	if b == nil || b.path == "" {
+		if b == nil {
+			return ""
+		}
+		return b.static
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return b.cached
+	}
+	if b.cached != "" && info.ModTime().Equal(b.modTime) {
+		return b.cached
+	}
+
+	data, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		return b.cached
+	}
+
+	b.cached = strings.TrimSpace(string(data))
+	b.modTime = info.ModTime()
+	return b.cached
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressionNone = "none"
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+// compressor compresses an outgoing request body and reports the
+// Content-Encoding value to advertise for it. Implementations are safe for
+// concurrent use.
+type compressor interface {
+	compress(data []byte) ([]byte, error)
+	contentEncoding() string
+}
+
+// newCompressor builds the compressor configured by compression_codec.
+func newCompressor(codecName string, level int) (compressor, error) {
+	switch codecName {
+	case "", compressionNone:
+		return noopCompressor{}, nil
+	case compressionGzip:
+		return newGzipCompressor(level)
+	case compressionZstd:
+		return newZstdCompressor(level)
+	default:
+		return nil, fmt.Errorf("unsupported compression_codec: %v", codecName)
+	}
+}
+
+type noopCompressor struct{}
+
+func (noopCompressor) compress(data []byte) ([]byte, error) { return data, nil }
+func (noopCompressor) contentEncoding() string              { return "" }
+
+// gzipCompressor pools gzip.Writers the same way httpOutput pools requests,
+// to avoid an allocation per flush.
+type gzipCompressor struct {
+	pool sync.Pool
+}
+
+func newGzipCompressor(level int) (*gzipCompressor, error) {
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, fmt.Errorf("invalid compression_level for gzip: %w", err)
+	}
+
+	c := &gzipCompressor{}
+	c.pool = sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(ioutil.Discard, level)
+			return w
+		},
+	}
+	return c, nil
+}
+
+func (c *gzipCompressor) compress(data []byte) ([]byte, error) {
+	w := c.pool.Get().(*gzip.Writer)
+	defer c.pool.Put(w)
+
+	var buf bytes.Buffer
+	w.Reset(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *gzipCompressor) contentEncoding() string { return compressionGzip }
+
+// zstdCompressor pools zstd.Encoders, mirroring gzipCompressor.
+type zstdCompressor struct {
+	pool sync.Pool
+}
+
+func newZstdCompressor(level int) (*zstdCompressor, error) {
+	encLevel := zstd.EncoderLevelFromZstd(level)
+
+	// build one encoder up front so a construction error is reported at
+	// startup rather than on the first flush. EncoderLevelFromZstd maps any
+	// level to the nearest zstd speed, so no level value is rejected.
+	probe, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encLevel))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compression_level for zstd: %w", err)
+	}
+	probe.Close()
+
+	c := &zstdCompressor{}
+	c.pool = sync.Pool{
+		New: func() interface{} {
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(encLevel))
+			return enc
+		},
+	}
+	return c, nil
+}
+
+func (c *zstdCompressor) compress(data []byte) ([]byte, error) {
+	enc := c.pool.Get().(*zstd.Encoder)
+	defer c.pool.Put(enc)
+
+	var buf bytes.Buffer
+	enc.Reset(&buf)
+	if _, err := enc.Write(data); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *zstdCompressor) contentEncoding() string { return compressionZstd }
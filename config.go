@@ -0,0 +1,93 @@
+package http
+
+import (
+	"compress/gzip"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+)
+
+type config struct {
+	URL         string       `config:"url"`
+	URLs        []string     `config:"urls"`
+	LoadBalance string       `config:"loadbalance"`
+	Codec       codec.Config `config:"codec"`
+
+	OnlyFields bool                   `config:"only_fields"`
+	AddFields  map[string]interface{} `config:"add_fields"`
+
+	Username string `config:"username"`
+	Password string `config:"password"`
+
+	// Headers are Go templates rendered per event (e.g. "{{.fields.dataset}}"),
+	// letting requests carry per-tenant metadata. URL templating reuses the
+	// url field itself instead of a separate key.
+	Headers         map[string]string `config:"headers"`
+	BearerToken     string            `config:"bearer_token"`
+	BearerTokenFile string            `config:"bearer_token_file"`
+
+	Compression bool `config:"compression"`
+	KeepAlive   bool `config:"keep_alive"`
+
+	// Request body compression. Compression above only toggles transparent
+	// response decompression on the transport.
+	CompressionCodec string `config:"compression_codec"`
+	CompressionLevel int    `config:"compression_level"`
+
+	MaxIdleConns          int `config:"max_idle_conns"`
+	IdleConnTimeout       int `config:"idle_conn_timeout"`
+	ResponseHeaderTimeout int `config:"response_header_timeout"`
+
+	TLS *tlscommon.Config `config:"tls"`
+
+	ProxyURL     string            `config:"proxy_url"`
+	ProxyHeaders map[string]string `config:"proxy_headers"`
+	HTTP2        bool              `config:"http2"`
+
+	RequestTimeout time.Duration `config:"request_timeout"`
+
+	MaxRetries int `config:"max_retries"`
+
+	// Batching
+	BatchSize     int           `config:"batch_size"`
+	BatchBytes    int           `config:"batch_bytes"`
+	FlushInterval time.Duration `config:"flush_interval"`
+
+	// Parsing. Parser selects which registered Parser runs before
+	// codec.Encode; the matching sub-config below configures it.
+	Parser       string         `config:"parser"`
+	OnParseError string         `config:"on_parse_error"`
+	ACLLog       aclLogConfig   `config:"acl_log"`
+	Regex        regexConfig    `config:"regex"`
+	Grok         grokConfig     `config:"grok"`
+	CSV          csvConfig      `config:"csv"`
+	JSONPath     jsonPathConfig `config:"json_path"`
+}
+
+var defaultConfig = config{
+	OnlyFields: false,
+
+	Compression: false,
+	KeepAlive:   true,
+
+	CompressionCodec: compressionNone,
+	CompressionLevel: gzip.DefaultCompression,
+
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90,
+	ResponseHeaderTimeout: 30000,
+
+	RequestTimeout: 30 * time.Second,
+
+	MaxRetries: 3,
+
+	BatchSize:     512,
+	BatchBytes:    1 << 20, // 1MiB
+	FlushInterval: 1 * time.Second,
+
+	Parser:       "none",
+	OnParseError: onParseErrorDrop,
+
+	LoadBalance: loadBalanceRoundRobin,
+}
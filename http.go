@@ -14,11 +14,10 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher"
 	"github.com/json-iterator/go"
 	"io/ioutil"
-	"net"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
-	"strings"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -30,14 +29,27 @@ func init() {
 }
 
 type httpOutput struct {
-	log       *logp.Logger
-	beat      beat.Info
-	observer  outputs.Observer
-	codec     codec.Codec
-	client    *http.Client
-	serialize func(event *publisher.Event) ([]byte, error)
-	reqPool   sync.Pool
-	conf      config
+	log        *logp.Logger
+	beat       beat.Info
+	observer   outputs.Observer
+	codec      codec.Codec
+	client     *http.Client
+	serialize  func(event *publisher.Event) ([]byte, error)
+	reqPool    sync.Pool
+	conf       config
+	compressor compressor
+	parser     Parser
+	lb         *loadBalancer
+
+	selector     *eventSelector
+	bearerTokens *bearerTokenSource
+
+	bufMu     sync.Mutex
+	buf       *eventBuffer
+	lastFlush time.Time
+
+	closeOnce sync.Once
+	doneC     chan struct{}
 }
 
 // makeHTTP instantiates a new http output instance.
@@ -60,9 +72,10 @@ func makeHTTP(
 		conf:     config,
 	}
 
-	// disable bulk support in publisher pipeline
-	if err := cfg.SetInt("bulk_max_size", -1, -1); err != nil {
-		ho.log.Error("Disable bulk error: ", err)
+	// let the pipeline batch events up to batch_size; we frame and flush
+	// them ourselves in Publish.
+	if err := cfg.SetInt("bulk_max_size", -1, int64(config.BatchSize)); err != nil {
+		ho.log.Error("Set bulk_max_size error: ", err)
 	}
 
 	//select serializer
@@ -88,39 +101,46 @@ func (out *httpOutput) init(beat beat.Info, c config) error {
 		return err
 	}
 
-	tr := &http.Transport{
-		MaxIdleConns:          out.conf.MaxIdleConns,
-		ResponseHeaderTimeout: time.Duration(out.conf.ResponseHeaderTimeout) * time.Millisecond,
-		IdleConnTimeout:       time.Duration(out.conf.IdleConnTimeout) * time.Second,
-		DisableCompression:    !out.conf.Compression,
-		DisableKeepAlives:     !out.conf.KeepAlive,
-		DialContext: func(ctx context.Context, network string, addr string) (conn net.Conn, err error) {
-			host, port, err := net.SplitHostPort(addr)
-			if err != nil {
-				return nil, err
-			}
-			ips, err := dnsCache.LookupHost(ctx, host)
-			if err != nil {
-				return nil, err
-			}
-			for _, ip := range ips {
-				var dialer net.Dialer
-				conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
-				if err == nil {
-					break
-				}
-			}
-			return
-		},
+	out.compressor, err = newCompressor(c.CompressionCodec, c.CompressionLevel)
+	if err != nil {
+		return err
+	}
+
+	out.parser, err = newParser(c.Parser, c)
+	if err != nil {
+		return err
+	}
+
+	urls, err := resolveURLs(c)
+	if err != nil {
+		return err
+	}
+	out.lb = newLoadBalancer(urls, c.LoadBalance)
+
+	out.selector, err = newEventSelector(c, urls)
+	if err != nil {
+		return err
+	}
+	out.bearerTokens = newBearerTokenSource(c)
+
+	tr, err := buildTransport(c)
+	if err != nil {
+		return err
 	}
 
 	out.client = &http.Client{
 		Transport: tr,
 	}
 
+	out.buf = newEventBuffer()
+	out.lastFlush = time.Now()
+	out.doneC = make(chan struct{})
+
 	out.reqPool = sync.Pool{
 		New: func() interface{} {
-			req, err := http.NewRequest("POST", out.conf.URL, nil)
+			// URL is overwritten per attempt in getReq to target whichever
+			// endpoint the load balancer picked; this is just a template.
+			req, err := http.NewRequestWithContext(context.Background(), "POST", urls[0], nil)
 			if err != nil {
 				return err
 			}
@@ -129,20 +149,31 @@ func (out *httpOutput) init(beat beat.Info, c config) error {
 	}
 
 	out.log.Infof("Initialized http output:\n"+
-		"url=%v\n"+
+		"urls=%v\n"+
+		"loadbalance=%v\n"+
 		"codec=%v\n"+
 		"only_fields=%v\n"+
+		"parser=%v\n"+
+		"on_parse_error=%v\n"+
 		"max_retries=%v\n"+
+		"batch_size=%v\n"+
+		"batch_bytes=%v\n"+
+		"flush_interval=%v\n"+
 		"compression=%v\n"+
+		"compression_codec=%v\n"+
+		"compression_level=%v\n"+
 		"keep_alive=%v\n"+
 		"max_idle_conns=%v\n"+
 		"idle_conn_timeout=%vs\n"+
 		"response_header_timeout=%vms\n"+
+		"proxy_url=%v\n"+
+		"http2=%v\n"+
 		"username=%v\n"+
-		"password=%v\n",
-		c.URL, c.Codec, c.OnlyFields, c.MaxRetries, c.Compression,
-		c.KeepAlive, c.MaxIdleConns, c.IdleConnTimeout, c.ResponseHeaderTimeout,
-		c.Username, maskPass(c.Password))
+		"password=%v\n"+
+		"dynamic_routing=%v\n",
+		urls, c.LoadBalance, c.Codec, c.OnlyFields, c.Parser, c.OnParseError, c.MaxRetries, c.BatchSize, c.BatchBytes, c.FlushInterval,
+		c.Compression, c.CompressionCodec, c.CompressionLevel, c.KeepAlive, c.MaxIdleConns,
+		c.IdleConnTimeout, c.ResponseHeaderTimeout, c.ProxyURL, c.HTTP2, c.Username, maskPass(c.Password), out.selector != nil)
 	return nil
 }
 
@@ -168,49 +199,26 @@ func maskPass(password string) string {
 
 // Implement Client
 func (out *httpOutput) Close() error {
+	out.closeOnce.Do(func() {
+		close(out.doneC)
+		for _, ep := range out.lb.endpoints {
+			successes, failures := ep.counts()
+			out.log.Infof("Endpoint %s lifetime delivery stats: successes=%d failures=%d", ep.url, successes, failures)
+		}
+	})
 	out.client.CloseIdleConnections()
 	return nil
 }
 
+// serializeOnlyFields marshals event.Content.Fields directly instead of
+// going through codec.Encode. Field extraction from the raw log line
+// happens earlier, in the configured Parser.
 func (out *httpOutput) serializeOnlyFields(event *publisher.Event) ([]byte, error) {
 	fields := event.Content.Fields
 	fields["@timestamp"] = event.Content.Timestamp
 	for key, val := range out.conf.AddFields {
 		fields[key] = val
 	}
-	body, err := fields.GetValue("body")
-
-	slice := strings.Split(body.(string), " ")
-	
-	if err != nil {
-		out.log.Error("slice error: ", err)
-		return make([]byte, 0), err
-	}
-
-	fields["ifindex"] = slice[3]
-	fields["actionCode"] = slice[4]
-	fields["aclTag"] = slice[6]
-	fields["ruleDesc"] = slice[8]
-	fields["protocol"] = slice[9]
-	fields["NFFOrDash"] = slice[10]
-	fields["srcIp"] = slice[11]
-	fields["srcPort"] = slice[12]
-	fields["dstIp"] = slice[13]
-	fields["dstPort"] = slice[14]
-	fields["octProto"] = slice[17]
-	fields["isInput"] = slice[19]
-	fields["isSlowpath"] = slice[21]
-	fields["hexFlegs"] = slice[23]
-	fields["invalidOrDash"] = slice[25]
-	fields["tcpflags"] = slice[26]
-	fields["rsvd"] = slice[28]
-
-	if len(slice) > 29{
-		fields["dur"] = slice[30]
-		fields["pkts"] = slice[32]
-		fields["bytes"] = slice[34]
-	}
-
 
 	serializedEvent, err := json.Marshal(&fields)
 	if err != nil {
@@ -229,7 +237,7 @@ func (out *httpOutput) serializeAll(event *publisher.Event) ([]byte, error) {
 	return serializedEvent, nil
 }
 
-func (out *httpOutput) Publish(_ context.Context, batch publisher.Batch) error {
+func (out *httpOutput) Publish(ctx context.Context, batch publisher.Batch) error {
 	st := out.observer
 	events := batch.Events()
 	st.NewBatch(len(events))
@@ -239,11 +247,53 @@ func (out *httpOutput) Publish(_ context.Context, batch publisher.Batch) error {
 		return nil
 	}
 
+	if out.selector != nil {
+		return out.publishPerEvent(ctx, batch, events)
+	}
+
+	out.bufMu.Lock()
+	defer out.bufMu.Unlock()
+
+	// resolved tracks, per original event index, whether the event has
+	// already been permanently accounted for (delivered or dropped) so a
+	// later flush failure only retries the genuine remainder instead of
+	// re-sending events a prior flush already shipped.
+	resolved := make([]bool, len(events))
+	dropped := 0
+	delivered := 0
+
+	flushBuffered := func() error {
+		idxs := out.buf.events
+		if err := out.flush(ctx); err != nil {
+			return err
+		}
+		for _, idx := range idxs {
+			resolved[idx] = true
+		}
+		delivered += len(idxs)
+		return nil
+	}
+
 	for i := range events {
 		event := events[i]
 
-		serializedEvent, err := out.serialize(&event)
+		if err := out.parser.Parse(&event); err != nil {
+			switch out.conf.OnParseError {
+			case onParseErrorTag:
+				event.Content.Fields["parse_error"] = err.Error()
+			case onParseErrorPassthrough:
+				// keep the event as-is and serialize it unparsed
+			default: // onParseErrorDrop
+				out.log.Warnf("Failed to parse event, dropping: %+v", err)
+				out.log.Debugf("Failed event: %v", event)
+				dropped++
+				resolved[i] = true
+				st.Dropped(1)
+				continue
+			}
+		}
 
+		serializedEvent, err := out.serialize(&event)
 		if err != nil {
 			if event.Guaranteed() {
 				out.log.Errorf("Failed to serialize the event: %+v", err)
@@ -252,43 +302,304 @@ func (out *httpOutput) Publish(_ context.Context, batch publisher.Batch) error {
 			}
 			out.log.Debugf("Failed event: %v", event)
 
-			batch.RetryEvents(events)
-			st.Failed(len(events))
-			return nil
+			dropped++
+			resolved[i] = true
+			st.Dropped(1)
+			continue
 		}
 
-		if err = out.send(serializedEvent); err != nil {
-			if event.Guaranteed() {
-				out.log.Errorf("Writing event to http failed with: %+v", err)
-			} else {
-				out.log.Warnf("Writing event to http failed with: %+v", err)
+		out.buf.add(i, serializedEvent)
+
+		overdue := time.Since(out.lastFlush) >= out.conf.FlushInterval
+		if out.buf.full(out.conf.BatchSize, out.conf.BatchBytes) || overdue {
+			if err := flushBuffered(); err != nil {
+				if event.Guaranteed() {
+					out.log.Errorf("Flushing batch to http failed with: %+v", err)
+				} else {
+					out.log.Warnf("Flushing batch to http failed with: %+v", err)
+				}
+
+				return out.retryUnresolved(batch, events, resolved, delivered, st)
+			}
+		}
+	}
+
+	if err := flushBuffered(); err != nil {
+		out.log.Warnf("Flushing final batch to http failed with: %+v", err)
+		return out.retryUnresolved(batch, events, resolved, delivered, st)
+	}
+
+	batch.ACK()
+	st.Acked(len(events) - dropped)
+	return nil
+}
+
+// retryUnresolved hands batch.RetryEvents only the events that were neither
+// delivered by an earlier flush in this Publish call nor already dropped,
+// so a partial-batch failure doesn't re-send events already shipped over
+// the wire or re-queue events intentionally dropped (e.g. on_parse_error:
+// drop).
+func (out *httpOutput) retryUnresolved(batch publisher.Batch, events []publisher.Event, resolved []bool, delivered int, st outputs.Observer) error {
+	remaining := make([]publisher.Event, 0, len(events))
+	for i, event := range events {
+		if !resolved[i] {
+			remaining = append(remaining, event)
+		}
+	}
+
+	batch.RetryEvents(remaining)
+	st.Failed(len(remaining))
+	if delivered > 0 {
+		st.Acked(delivered)
+	}
+	return nil
+}
+
+// publishPerEvent handles outputs configured with a dynamic url/headers
+// template (see eventSelector). Since the resolved target can differ per
+// event, each one is parsed, serialized and flushed as its own request
+// instead of being folded into a shared NDJSON batch.
+//
+// When the url itself is templated, the resolved destination is specific
+// to that event (a tenant collector) and there's nothing else to fail
+// over to, so it's sent with a plain retry. When only headers are
+// templated, the url is still one of the configured load-balanced
+// endpoints, so the event is routed through out.lb the same way flush
+// does for batches, carrying the resolved headers along.
+func (out *httpOutput) publishPerEvent(ctx context.Context, batch publisher.Batch, events []publisher.Event) error {
+	st := out.observer
+
+	dropped := 0
+	acked := 0
+	for i := range events {
+		event := events[i]
+
+		if err := out.parser.Parse(&event); err != nil {
+			switch out.conf.OnParseError {
+			case onParseErrorTag:
+				event.Content.Fields["parse_error"] = err.Error()
+			case onParseErrorPassthrough:
+				// keep the event as-is and serialize it unparsed
+			default: // onParseErrorDrop
+				out.log.Warnf("Failed to parse event, dropping: %+v", err)
+				dropped++
+				st.Dropped(1)
+				continue
 			}
+		}
+
+		serializedEvent, err := out.serialize(&event)
+		if err != nil {
+			out.log.Warnf("Failed to serialize the event: %+v", err)
+			dropped++
+			st.Dropped(1)
+			continue
+		}
+
+		headers := out.selector.resolveHeaders(&event)
+
+		var sendErr error
+		var permanent bool
+		if out.selector.usesURLTemplate() {
+			tgt := &target{url: out.selector.resolveURL(&event), headers: headers}
+			sendErr = out.sendWithRetry(ctx, tgt, serializedEvent)
+			var serr *statusError
+			permanent = errors.As(sendErr, &serr) && isPermanentStatus(serr.code)
+		} else {
+			sendErr, permanent = out.sendWithFailover(ctx, serializedEvent, headers)
+		}
 
-			//batch.RetryEvents(events)
-			st.Failed(len(events))
+		if sendErr != nil {
+			if permanent {
+				out.log.Errorf("Permanent error delivering event, dropping: %+v", sendErr)
+				dropped++
+				st.Dropped(1)
+				continue
+			}
+
+			out.log.Warnf("Delivering event failed with: %+v", sendErr)
+			batch.RetryEvents(events)
+			st.Failed(len(events) - dropped)
 			return nil
 		}
+
+		acked++
 	}
 
 	batch.ACK()
-	st.Acked(len(events))
+	st.Acked(acked)
 	return nil
 }
 
+// sendWithRetry retries a single-event request against tgt using the same
+// exponential-backoff policy flush uses for batches. Used only for
+// url-templated targets, which have no other endpoint to fail over to.
+func (out *httpOutput) sendWithRetry(ctx context.Context, tgt *target, data []byte) error {
+	var err error
+	for attempt := 0; attempt <= out.conf.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDuration(attempt - 1))
+		}
+
+		err = out.send(ctx, tgt, data)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		var serr *statusError
+		if errors.As(err, &serr) && isPermanentStatus(serr.code) {
+			return err
+		}
+	}
+
+	return err
+}
+
 func (out *httpOutput) String() string {
-	return "http(" + out.conf.URL + ")"
+	return "http(" + out.conf.LoadBalance + ")"
+}
+
+// flush sends the currently buffered events as a single NDJSON body,
+// retrying transient failures with exponential backoff up to MaxRetries.
+// Each attempt tries the load-balanced endpoints in turn, moving to the
+// next on a retryable failure. Permanent (4xx, except 429) failures are
+// not retried. The buffer is always reset on return; the caller is
+// responsible for requeuing events on error.
+func (out *httpOutput) flush(ctx context.Context) error {
+	if out.buf.empty() {
+		return nil
+	}
+	defer func() {
+		out.buf.reset()
+		out.lastFlush = time.Now()
+	}()
+
+	err, permanent := out.sendWithFailover(ctx, out.buf.data, nil)
+	if err == nil {
+		return nil
+	}
+
+	if permanent {
+		out.log.Errorf("Permanent error delivering batch, dropping %d events: %+v", len(out.buf.events), err)
+		out.observer.Dropped(len(out.buf.events))
+		return nil
+	}
+
+	return err
 }
 
-func (out *httpOutput) send(data []byte) error {
+// sendWithFailover retries data across the load-balanced endpoints with
+// exponential backoff up to MaxRetries, stopping early on a permanent
+// failure or shutdown. headers, when non-nil, are applied on top of each
+// attempt (per-event header templating riding along with failover).
+func (out *httpOutput) sendWithFailover(ctx context.Context, data []byte, headers map[string]string) (error, bool) {
+	var err error
+	for attempt := 0; attempt <= out.conf.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDuration(attempt - 1))
+		}
+
+		var permanent bool
+		err, permanent = out.sendToEndpoints(ctx, data, headers)
+		if err == nil || permanent {
+			return err, permanent
+		}
+
+		if errors.Is(err, context.Canceled) {
+			// output is shutting down; stop retrying so the batch can be
+			// requeued immediately instead of burning the backoff window.
+			return err, false
+		}
+	}
+
+	return err, false
+}
+
+// sendToEndpoints tries the load balancer's candidate endpoints in order,
+// recording health on each, and moves to the next on a retryable failure.
+// It returns the last error seen and whether that error was permanent.
+func (out *httpOutput) sendToEndpoints(ctx context.Context, data []byte, headers map[string]string) (error, bool) {
+	var lastErr error
+	for _, ep := range out.lb.order() {
+		err := out.send(ctx, &target{url: ep.url, headers: headers}, data)
+		if err == nil {
+			ep.recordSuccess()
+			return nil, false
+		}
+
+		if errors.Is(err, context.Canceled) {
+			return err, false
+		}
+
+		var serr *statusError
+		if errors.As(err, &serr) && isPermanentStatus(serr.code) {
+			// the endpoint answered; it's the payload that's bad.
+			ep.recordSuccess()
+			return err, true
+		}
+
+		ep.recordFailure(err)
+		successes, failures := ep.counts()
+		out.log.Warnf("Delivery to %s failed (successes=%d failures=%d), trying next endpoint: %+v",
+			ep.url, successes, failures, err)
+		lastErr = err
+	}
+
+	return lastErr, false
+}
 
-	req, err := out.getReq(data)
+// statusError wraps a non-200 HTTP response so callers can distinguish
+// permanent (4xx) from retryable (5xx, 429) failures.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("bad response code: %d", e.code)
+}
+
+// send issues a single POST request for data against tgt, bounded by
+// request_timeout and aborted early if the output is closed mid-flight.
+func (out *httpOutput) send(ctx context.Context, tgt *target, data []byte) error {
+
+	req, err := out.getReq(tgt, data)
 	if err != nil {
 		return err
 	}
 	defer out.putReq(req)
 
-	resp, err := out.client.Do(req)
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	timedOut := make(chan struct{})
+	timer := time.AfterFunc(out.conf.RequestTimeout, func() {
+		close(timedOut)
+		cancel()
+	})
+	defer timer.Stop()
+
+	watchDone := make(chan struct{})
+	defer close(watchDone)
+	go func() {
+		select {
+		case <-out.doneC:
+			cancel()
+		case <-watchDone:
+		}
+	}()
+
+	resp, err := out.client.Do(req.WithContext(reqCtx))
 	if err != nil {
+		select {
+		case <-timedOut:
+			// a request_timeout is a retryable failure, not a shutdown.
+			return fmt.Errorf("request timed out after %v: %w", out.conf.RequestTimeout, context.DeadlineExceeded)
+		default:
+		}
 		return err
 	}
 
@@ -298,21 +609,49 @@ func (out *httpOutput) send(data []byte) error {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad response code: %d", resp.StatusCode)
+		return &statusError{code: resp.StatusCode}
 	}
 
 	return nil
 }
 
-func (out *httpOutput) getReq(data []byte) (*http.Request, error) {
+func (out *httpOutput) getReq(tgt *target, data []byte) (*http.Request, error) {
 	tmp := out.reqPool.Get()
 
 	req, ok := tmp.(*http.Request)
 	if ok {
-		buf := bytes.NewBuffer(data)
+		parsed, err := url.Parse(tgt.url)
+		if err != nil {
+			out.reqPool.Put(req)
+			return nil, err
+		}
+		req.URL = parsed
+		req.Host = parsed.Host
+
+		body := data
+		if enc := out.compressor.contentEncoding(); enc != "" {
+			compressed, err := out.compressor.compress(data)
+			if err != nil {
+				out.reqPool.Put(req)
+				return nil, err
+			}
+			body = compressed
+			req.Header.Set("Content-Encoding", enc)
+		} else {
+			req.Header.Del("Content-Encoding")
+		}
+
+		buf := bytes.NewBuffer(body)
 		req.Body = ioutil.NopCloser(buf)
+		req.ContentLength = int64(len(body))
 		req.Header.Set("User-Agent", "beat "+out.beat.Version)
 		req.Header.Set("Content-Type", "application/json")
+		for k, v := range tgt.headers {
+			req.Header.Set(k, v)
+		}
+		if token := out.bearerTokens.token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
 		if out.conf.Username != "" {
 			req.SetBasicAuth(out.conf.Username, out.conf.Password)
 		}
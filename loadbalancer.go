@@ -0,0 +1,136 @@
+package http
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	loadBalanceRoundRobin = "round_robin"
+	loadBalanceRandom     = "random"
+	loadBalanceFailover   = "failover"
+)
+
+// resolveURLs returns the endpoint list to load-balance across. urls takes
+// precedence; url is kept as single-endpoint sugar for existing configs.
+func resolveURLs(c config) ([]string, error) {
+	if len(c.URLs) > 0 {
+		return c.URLs, nil
+	}
+	if c.URL != "" {
+		return []string{c.URL}, nil
+	}
+	return nil, fmt.Errorf("no url or urls configured")
+}
+
+// endpoint tracks delivery health for one destination URL so an unhealthy
+// target is skipped until its backoff window elapses, rather than eating a
+// request timeout on every attempt.
+type endpoint struct {
+	url string
+
+	successes uint64
+	failures  uint64
+
+	mu               sync.Mutex
+	consecutiveFails int
+	lastErr          error
+	retryAfter       time.Time
+}
+
+// counts returns the endpoint's lifetime success/failure totals, for
+// surfacing per-URL health in logs.
+func (e *endpoint) counts() (successes, failures uint64) {
+	return atomic.LoadUint64(&e.successes), atomic.LoadUint64(&e.failures)
+}
+
+func (e *endpoint) healthy(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !now.Before(e.retryAfter)
+}
+
+func (e *endpoint) recordSuccess() {
+	atomic.AddUint64(&e.successes, 1)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.consecutiveFails = 0
+	e.lastErr = nil
+	e.retryAfter = time.Time{}
+}
+
+func (e *endpoint) recordFailure(err error) {
+	atomic.AddUint64(&e.failures, 1)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.consecutiveFails++
+	e.lastErr = err
+	e.retryAfter = time.Now().Add(backoffDuration(e.consecutiveFails - 1))
+}
+
+// loadBalancer picks the order in which to try the configured endpoints,
+// per the chosen strategy, preferring endpoints that aren't in their
+// failure backoff window.
+type loadBalancer struct {
+	strategy  string
+	endpoints []*endpoint
+
+	mu   sync.Mutex
+	next int
+}
+
+func newLoadBalancer(urls []string, strategy string) *loadBalancer {
+	endpoints := make([]*endpoint, len(urls))
+	for i, u := range urls {
+		endpoints[i] = &endpoint{url: u}
+	}
+	return &loadBalancer{strategy: strategy, endpoints: endpoints}
+}
+
+// order returns the candidate endpoints to try; endpoints inside their
+// backoff window are left out. If every endpoint is currently backed off,
+// it falls back to trying all of them anyway rather than failing the batch
+// outright.
+func (lb *loadBalancer) order() []*endpoint {
+	now := time.Now()
+
+	healthy := make([]*endpoint, 0, len(lb.endpoints))
+	for _, ep := range lb.endpoints {
+		if ep.healthy(now) {
+			healthy = append(healthy, ep)
+		}
+	}
+
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = lb.endpoints
+	}
+
+	switch lb.strategy {
+	case loadBalanceRandom:
+		shuffled := append([]*endpoint(nil), candidates...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		return shuffled
+
+	case loadBalanceFailover:
+		// declared order: the first healthy endpoint absorbs all traffic
+		// until it starts failing.
+		return candidates
+
+	default: // round_robin
+		lb.mu.Lock()
+		start := lb.next % len(candidates)
+		lb.next++
+		lb.mu.Unlock()
+
+		ordered := make([]*endpoint, 0, len(candidates))
+		ordered = append(ordered, candidates[start:]...)
+		ordered = append(ordered, candidates[:start]...)
+		return ordered
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolveURLs(t *testing.T) {
+	if _, err := resolveURLs(config{}); err == nil {
+		t.Fatalf("expected error when neither url nor urls is set")
+	}
+
+	urls, err := resolveURLs(config{URL: "http://a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := urls, []string{"http://a"}; len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("resolveURLs(url) = %v, want %v", got, want)
+	}
+
+	// urls takes precedence over url.
+	urls, err = resolveURLs(config{URL: "http://a", URLs: []string{"http://b", "http://c"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := len(urls), 2; got != want {
+		t.Fatalf("resolveURLs precedence: len = %d, want %d", got, want)
+	}
+}
+
+func TestLoadBalancerRoundRobinRotates(t *testing.T) {
+	lb := newLoadBalancer([]string{"http://a", "http://b", "http://c"}, loadBalanceRoundRobin)
+
+	first := lb.order()
+	second := lb.order()
+
+	if first[0].url == second[0].url {
+		t.Fatalf("round_robin should rotate the starting endpoint between calls, got %s both times", first[0].url)
+	}
+	if len(first) != 3 || len(second) != 3 {
+		t.Fatalf("round_robin should return all endpoints each call")
+	}
+}
+
+func TestLoadBalancerFailoverPreservesOrder(t *testing.T) {
+	lb := newLoadBalancer([]string{"http://a", "http://b", "http://c"}, loadBalanceFailover)
+
+	for i := 0; i < 3; i++ {
+		order := lb.order()
+		if order[0].url != "http://a" || order[1].url != "http://b" || order[2].url != "http://c" {
+			t.Fatalf("failover order = %v, want declared order a,b,c", order)
+		}
+	}
+}
+
+func TestEndpointHealthWindow(t *testing.T) {
+	e := &endpoint{url: "http://a"}
+
+	if !e.healthy(time.Now()) {
+		t.Fatalf("a fresh endpoint should be healthy")
+	}
+
+	e.recordFailure(errors.New("boom"))
+	if e.healthy(time.Now()) {
+		t.Fatalf("endpoint should be unhealthy immediately after a failure")
+	}
+
+	successes, failures := e.counts()
+	if successes != 0 || failures != 1 {
+		t.Fatalf("counts() = (%d, %d), want (0, 1)", successes, failures)
+	}
+
+	e.recordSuccess()
+	if !e.healthy(time.Now()) {
+		t.Fatalf("endpoint should be healthy again immediately after a success")
+	}
+
+	successes, failures = e.counts()
+	if successes != 1 || failures != 1 {
+		t.Fatalf("counts() = (%d, %d), want (1, 1)", successes, failures)
+	}
+}
+
+func TestLoadBalancerOrderFallsBackWhenAllUnhealthy(t *testing.T) {
+	lb := newLoadBalancer([]string{"http://a", "http://b"}, loadBalanceRoundRobin)
+
+	for _, ep := range lb.endpoints {
+		ep.recordFailure(errors.New("boom"))
+	}
+
+	order := lb.order()
+	if len(order) != 2 {
+		t.Fatalf("order() should still try every endpoint when all are unhealthy, got %d", len(order))
+	}
+}
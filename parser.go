@@ -0,0 +1,48 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+const (
+	onParseErrorDrop        = "drop"
+	onParseErrorTag         = "tag"
+	onParseErrorPassthrough = "passthrough"
+)
+
+// Parser extracts structured fields from a raw event before it reaches
+// codec.Encode. Implementations mutate event.Content.Fields in place and
+// read their own settings off the output config (e.g. conf.Regex).
+type Parser interface {
+	Parse(event *publisher.Event) error
+}
+
+type parserFactory func(c config) (Parser, error)
+
+var parserRegistry = map[string]parserFactory{
+	"none":      func(c config) (Parser, error) { return noopParser{}, nil },
+	"acl_log":   newACLLogParser,
+	"regex":     newRegexParser,
+	"grok":      newGrokParser,
+	"csv":       newCSVParser,
+	"json_path": newJSONPathParser,
+}
+
+// newParser builds the Parser registered under name, defaulting to a no-op
+// when name is empty.
+func newParser(name string, c config) (Parser, error) {
+	if name == "" {
+		name = "none"
+	}
+	factory, ok := parserRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parser: %v", name)
+	}
+	return factory(c)
+}
+
+type noopParser struct{}
+
+func (noopParser) Parse(*publisher.Event) error { return nil }
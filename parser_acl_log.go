@@ -0,0 +1,98 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+// aclLogField maps a position in a delimited ACL log line to a field name.
+type aclLogField struct {
+	Index int    `config:"index"`
+	Name  string `config:"name"`
+}
+
+type aclLogConfig struct {
+	SourceField string        `config:"source_field"`
+	Delimiter   string        `config:"delimiter"`
+	Fields      []aclLogField `config:"fields"`
+}
+
+// defaultACLLogFields reproduces the field layout this output originally
+// hard-coded for the firewall/ACL log format.
+var defaultACLLogFields = []aclLogField{
+	{Index: 3, Name: "ifindex"},
+	{Index: 4, Name: "actionCode"},
+	{Index: 6, Name: "aclTag"},
+	{Index: 8, Name: "ruleDesc"},
+	{Index: 9, Name: "protocol"},
+	{Index: 10, Name: "NFFOrDash"},
+	{Index: 11, Name: "srcIp"},
+	{Index: 12, Name: "srcPort"},
+	{Index: 13, Name: "dstIp"},
+	{Index: 14, Name: "dstPort"},
+	{Index: 17, Name: "octProto"},
+	{Index: 19, Name: "isInput"},
+	{Index: 21, Name: "isSlowpath"},
+	{Index: 23, Name: "hexFlegs"},
+	{Index: 25, Name: "invalidOrDash"},
+	{Index: 26, Name: "tcpflags"},
+	{Index: 28, Name: "rsvd"},
+	{Index: 30, Name: "dur"},
+	{Index: 32, Name: "pkts"},
+	{Index: 34, Name: "bytes"},
+}
+
+// aclLogParser splits a space-delimited ACL log line and assigns positional
+// fields by index, per a configurable field map. Out-of-range indices are
+// skipped rather than erroring, since trailing fields (dur/pkts/bytes) are
+// only present on some log variants.
+type aclLogParser struct {
+	sourceField string
+	delimiter   string
+	fields      []aclLogField
+}
+
+func newACLLogParser(c config) (Parser, error) {
+	conf := c.ACLLog
+
+	sourceField := conf.SourceField
+	if sourceField == "" {
+		sourceField = "body"
+	}
+
+	delimiter := conf.Delimiter
+	if delimiter == "" {
+		delimiter = " "
+	}
+
+	fields := conf.Fields
+	if len(fields) == 0 {
+		fields = defaultACLLogFields
+	}
+
+	return &aclLogParser{sourceField: sourceField, delimiter: delimiter, fields: fields}, nil
+}
+
+func (p *aclLogParser) Parse(event *publisher.Event) error {
+	fields := event.Content.Fields
+
+	raw, err := fields.GetValue(p.sourceField)
+	if err != nil {
+		return err
+	}
+	body, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("acl_log: %s field is not a string", p.sourceField)
+	}
+
+	slice := strings.Split(body, p.delimiter)
+	for _, f := range p.fields {
+		if f.Index < 0 || f.Index >= len(slice) {
+			continue
+		}
+		fields[f.Name] = slice[f.Index]
+	}
+	return nil
+}
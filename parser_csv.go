@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+type csvConfig struct {
+	SourceField string   `config:"source_field"`
+	Delimiter   string   `config:"delimiter"`
+	Header      []string `config:"header"`
+}
+
+// csvParser splits a single CSV/TSV record and assigns fields by header
+// position.
+type csvParser struct {
+	sourceField string
+	delimiter   rune
+	header      []string
+}
+
+func newCSVParser(c config) (Parser, error) {
+	conf := c.CSV
+	if len(conf.Header) == 0 {
+		return nil, fmt.Errorf("csv parser requires a header")
+	}
+
+	sourceField := conf.SourceField
+	if sourceField == "" {
+		sourceField = "body"
+	}
+
+	delimiter := ','
+	if conf.Delimiter != "" {
+		delimiter = []rune(conf.Delimiter)[0]
+	}
+
+	return &csvParser{sourceField: sourceField, delimiter: delimiter, header: conf.Header}, nil
+}
+
+func (p *csvParser) Parse(event *publisher.Event) error {
+	fields := event.Content.Fields
+
+	raw, err := fields.GetValue(p.sourceField)
+	if err != nil {
+		return err
+	}
+	body, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("csv: %s field is not a string", p.sourceField)
+	}
+
+	r := csv.NewReader(strings.NewReader(body))
+	r.Comma = p.delimiter
+
+	record, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("csv: %w", err)
+	}
+
+	for i, name := range p.header {
+		if i >= len(record) {
+			break
+		}
+		fields[name] = record[i]
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+type grokConfig struct {
+	SourceField string `config:"source_field"`
+	Pattern     string `config:"pattern"`
+}
+
+// grokPatterns is the small builtin set of grok identifiers this output
+// understands, mapped to their regex equivalent.
+var grokPatterns = map[string]string{
+	"WORD":       `\w+`,
+	"NUMBER":     `[+-]?\d+(?:\.\d+)?`,
+	"IP":         `[0-9.]+`,
+	"DATA":       `.*?`,
+	"GREEDYDATA": `.*`,
+	"NOTSPACE":   `\S+`,
+}
+
+var grokToken = regexp.MustCompile(`%\{(\w+)(?::(\w+))?\}`)
+
+// compileGrok translates a grok-style pattern such as
+// "%{IP:src} %{NUMBER:port}" into a regexp with named capture groups.
+func compileGrok(pattern string) (*regexp.Regexp, error) {
+	var out strings.Builder
+	last := 0
+
+	for _, loc := range grokToken.FindAllStringSubmatchIndex(pattern, -1) {
+		out.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+
+		typeName := pattern[loc[2]:loc[3]]
+		fieldName := ""
+		if loc[4] != -1 {
+			fieldName = pattern[loc[4]:loc[5]]
+		}
+
+		re, ok := grokPatterns[typeName]
+		if !ok {
+			return nil, fmt.Errorf("unknown grok pattern: %%{%s}", typeName)
+		}
+
+		if fieldName != "" {
+			out.WriteString(fmt.Sprintf("(?P<%s>%s)", fieldName, re))
+		} else {
+			out.WriteString("(?:" + re + ")")
+		}
+
+		last = loc[1]
+	}
+	out.WriteString(regexp.QuoteMeta(pattern[last:]))
+
+	return regexp.Compile(out.String())
+}
+
+// grokParser is a regexParser whose pattern is compiled from grok syntax
+// instead of being written as raw regex.
+type grokParser struct {
+	sourceField string
+	re          *regexp.Regexp
+	names       []string
+}
+
+func newGrokParser(c config) (Parser, error) {
+	conf := c.Grok
+	if conf.Pattern == "" {
+		return nil, fmt.Errorf("grok parser requires a pattern")
+	}
+
+	re, err := compileGrok(conf.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceField := conf.SourceField
+	if sourceField == "" {
+		sourceField = "body"
+	}
+
+	return &grokParser{sourceField: sourceField, re: re, names: re.SubexpNames()}, nil
+}
+
+func (p *grokParser) Parse(event *publisher.Event) error {
+	fields := event.Content.Fields
+
+	raw, err := fields.GetValue(p.sourceField)
+	if err != nil {
+		return err
+	}
+	body, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("grok: %s field is not a string", p.sourceField)
+	}
+
+	match := p.re.FindStringSubmatch(body)
+	if match == nil {
+		return fmt.Errorf("grok: pattern did not match")
+	}
+
+	for i, name := range p.names {
+		if name == "" {
+			continue
+		}
+		fields[name] = match[i]
+	}
+	return nil
+}
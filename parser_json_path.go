@@ -0,0 +1,67 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/json-iterator/go"
+)
+
+type jsonPathField struct {
+	Path string `config:"path"`
+	Name string `config:"name"`
+}
+
+type jsonPathConfig struct {
+	SourceField string          `config:"source_field"`
+	Fields      []jsonPathField `config:"fields"`
+}
+
+// jsonPathParser pulls nested values out of a JSON-encoded source field by
+// dotted path, e.g. "meta.tenant.id".
+type jsonPathParser struct {
+	sourceField string
+	fields      []jsonPathField
+}
+
+func newJSONPathParser(c config) (Parser, error) {
+	conf := c.JSONPath
+	if len(conf.Fields) == 0 {
+		return nil, fmt.Errorf("json_path parser requires at least one field")
+	}
+
+	sourceField := conf.SourceField
+	if sourceField == "" {
+		sourceField = "body"
+	}
+
+	return &jsonPathParser{sourceField: sourceField, fields: conf.Fields}, nil
+}
+
+func (p *jsonPathParser) Parse(event *publisher.Event) error {
+	fields := event.Content.Fields
+
+	raw, err := fields.GetValue(p.sourceField)
+	if err != nil {
+		return err
+	}
+	body, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("json_path: %s field is not a string", p.sourceField)
+	}
+
+	for _, f := range p.fields {
+		parts := make([]interface{}, 0, strings.Count(f.Path, ".")+1)
+		for _, part := range strings.Split(f.Path, ".") {
+			parts = append(parts, part)
+		}
+
+		value := json.Get([]byte(body), parts...)
+		if value.ValueType() == jsoniter.InvalidValue {
+			continue
+		}
+		fields[f.Name] = value.GetInterface()
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+type regexConfig struct {
+	SourceField string `config:"source_field"`
+	Pattern     string `config:"pattern"`
+}
+
+// regexParser extracts fields from a named-capture regular expression.
+type regexParser struct {
+	sourceField string
+	re          *regexp.Regexp
+	names       []string
+}
+
+func newRegexParser(c config) (Parser, error) {
+	conf := c.Regex
+	if conf.Pattern == "" {
+		return nil, fmt.Errorf("regex parser requires a pattern")
+	}
+
+	re, err := regexp.Compile(conf.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	names := re.SubexpNames()
+	named := false
+	for _, n := range names {
+		if n != "" {
+			named = true
+			break
+		}
+	}
+	if !named {
+		return nil, fmt.Errorf("regex pattern must contain at least one named capture group")
+	}
+
+	sourceField := conf.SourceField
+	if sourceField == "" {
+		sourceField = "body"
+	}
+
+	return &regexParser{sourceField: sourceField, re: re, names: names}, nil
+}
+
+func (p *regexParser) Parse(event *publisher.Event) error {
+	fields := event.Content.Fields
+
+	raw, err := fields.GetValue(p.sourceField)
+	if err != nil {
+		return err
+	}
+	body, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("regex: %s field is not a string", p.sourceField)
+	}
+
+	match := p.re.FindStringSubmatch(body)
+	if match == nil {
+		return fmt.Errorf("regex: pattern did not match")
+	}
+
+	for i, name := range p.names {
+		if name == "" {
+			continue
+		}
+		fields[name] = match[i]
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+func newTestEvent(fields common.MapStr) *publisher.Event {
+	return &publisher.Event{Content: beat.Event{Fields: fields}}
+}
+
+func TestNewParserUnknownName(t *testing.T) {
+	if _, err := newParser("does_not_exist", config{}); err == nil {
+		t.Fatalf("expected an error for an unregistered parser name")
+	}
+}
+
+func TestNewParserDefaultsToNoop(t *testing.T) {
+	p, err := newParser("", config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	event := newTestEvent(common.MapStr{"body": "untouched"})
+	if err := p.Parse(event); err != nil {
+		t.Fatalf("noop parser should never error: %v", err)
+	}
+	if event.Content.Fields["body"] != "untouched" {
+		t.Fatalf("noop parser should not modify fields")
+	}
+}
+
+func TestRegexParser(t *testing.T) {
+	p, err := newRegexParser(config{Regex: regexConfig{Pattern: `(?P<method>\w+) (?P<path>\S+)`}})
+	if err != nil {
+		t.Fatalf("unexpected error building parser: %v", err)
+	}
+
+	event := newTestEvent(common.MapStr{"body": "GET /health"})
+	if err := p.Parse(event); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if got := event.Content.Fields["method"]; got != "GET" {
+		t.Fatalf("method = %v, want GET", got)
+	}
+	if got := event.Content.Fields["path"]; got != "/health" {
+		t.Fatalf("path = %v, want /health", got)
+	}
+}
+
+func TestRegexParserNoMatch(t *testing.T) {
+	p, err := newRegexParser(config{Regex: regexConfig{Pattern: `(?P<n>\d+)`}})
+	if err != nil {
+		t.Fatalf("unexpected error building parser: %v", err)
+	}
+
+	event := newTestEvent(common.MapStr{"body": "no digits here"})
+	if err := p.Parse(event); err == nil {
+		t.Fatalf("expected an error when the pattern does not match")
+	}
+}
+
+func TestRegexParserRequiresNamedGroup(t *testing.T) {
+	if _, err := newRegexParser(config{Regex: regexConfig{Pattern: `\d+`}}); err == nil {
+		t.Fatalf("expected an error for a pattern with no named capture groups")
+	}
+}
+
+func TestRegexParserRequiresPattern(t *testing.T) {
+	if _, err := newRegexParser(config{}); err == nil {
+		t.Fatalf("expected an error when pattern is empty")
+	}
+}
+
+func TestCSVParser(t *testing.T) {
+	p, err := newCSVParser(config{CSV: csvConfig{Header: []string{"ts", "status", "path"}}})
+	if err != nil {
+		t.Fatalf("unexpected error building parser: %v", err)
+	}
+
+	event := newTestEvent(common.MapStr{"body": "2024-01-01,200,/health"})
+	if err := p.Parse(event); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if got := event.Content.Fields["status"]; got != "200" {
+		t.Fatalf("status = %v, want 200", got)
+	}
+}
+
+func TestCSVParserCustomDelimiter(t *testing.T) {
+	p, err := newCSVParser(config{CSV: csvConfig{Header: []string{"a", "b"}, Delimiter: ";"}})
+	if err != nil {
+		t.Fatalf("unexpected error building parser: %v", err)
+	}
+
+	event := newTestEvent(common.MapStr{"body": "1;2"})
+	if err := p.Parse(event); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if got := event.Content.Fields["b"]; got != "2" {
+		t.Fatalf("b = %v, want 2", got)
+	}
+}
+
+func TestCSVParserRequiresHeader(t *testing.T) {
+	if _, err := newCSVParser(config{}); err == nil {
+		t.Fatalf("expected an error when header is empty")
+	}
+}
+
+func TestJSONPathParser(t *testing.T) {
+	p, err := newJSONPathParser(config{JSONPath: jsonPathConfig{
+		Fields: []jsonPathField{{Path: "meta.tenant", Name: "tenant"}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error building parser: %v", err)
+	}
+
+	event := newTestEvent(common.MapStr{"body": `{"meta":{"tenant":"acme"}}`})
+	if err := p.Parse(event); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if got := event.Content.Fields["tenant"]; got != "acme" {
+		t.Fatalf("tenant = %v, want acme", got)
+	}
+}
+
+func TestJSONPathParserMissingPathIsSkipped(t *testing.T) {
+	p, err := newJSONPathParser(config{JSONPath: jsonPathConfig{
+		Fields: []jsonPathField{{Path: "meta.missing", Name: "missing"}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error building parser: %v", err)
+	}
+
+	event := newTestEvent(common.MapStr{"body": `{"meta":{}}`})
+	if err := p.Parse(event); err != nil {
+		t.Fatalf("a missing path should be skipped, not an error: %v", err)
+	}
+	if _, ok := event.Content.Fields["missing"]; ok {
+		t.Fatalf("field for a missing path should not be set")
+	}
+}
+
+func TestJSONPathParserRequiresFields(t *testing.T) {
+	if _, err := newJSONPathParser(config{}); err == nil {
+		t.Fatalf("expected an error when no fields are configured")
+	}
+}
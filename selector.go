@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+// target is a resolved request destination: a URL plus any extra headers
+// to set on top of the output's defaults.
+type target struct {
+	url     string
+	headers map[string]string
+}
+
+// eventSelector resolves a per-event request target from Go templates
+// referencing event fields and metadata, e.g.
+// "https://collector/{{.fields.tenant}}/ingest". This lets one output fan
+// events out to different endpoints/tenants based on their contents. A
+// nil *eventSelector means the output isn't configured for dynamic
+// routing.
+//
+// Only url templating replaces the destination outright: when just
+// headers are templated, the resolved headers are carried through the
+// load balancer's own endpoints instead (see publishPerEvent), so
+// chunk0-6's failover keeps working. url templating has no such fallback
+// set of endpoints to fail over to — it's inherently one URL per tenant —
+// so a resolution failure there falls back to the first non-templated
+// load-balanced endpoint rather than an unusable empty string.
+type eventSelector struct {
+	urlTmpl     *template.Template
+	headerTmpls map[string]*template.Template
+	fallbackURL string
+}
+
+// newEventSelector returns nil when url and headers are both static
+// strings, so callers can cheaply check for dynamic routing with a nil
+// comparison. urls is the output's resolved endpoint list (see
+// resolveURLs); it supplies the fallback url templating can't provide for
+// itself, and it is this function's only source of truth for "is there a
+// usable url to fall back to" — validation fails loudly instead of
+// shipping a selector that resolves to "".
+//
+// The fallback must itself be a plain, non-templated URL: if url is
+// templated and urls wasn't set separately, resolveURLs(c) just echoes
+// back the same template string, which is not a usable fallback (it would
+// render a literal "{{...}}" straight into the request). That case is
+// rejected rather than silently falling back to garbage — a templated url
+// needs a separate urls: pool to fall back to.
+func newEventSelector(c config, urls []string) (*eventSelector, error) {
+	if !strings.Contains(c.URL, "{{") && len(c.Headers) == 0 {
+		return nil, nil
+	}
+
+	fallbackURL := ""
+	for _, u := range urls {
+		if u != "" && !strings.Contains(u, "{{") {
+			fallbackURL = u
+			break
+		}
+	}
+	if fallbackURL == "" {
+		return nil, fmt.Errorf("dynamic url/headers routing requires a non-templated url in urls to fall back to")
+	}
+
+	sel := &eventSelector{
+		fallbackURL: fallbackURL,
+		headerTmpls: make(map[string]*template.Template, len(c.Headers)),
+	}
+
+	if strings.Contains(c.URL, "{{") {
+		t, err := template.New("url").Option("missingkey=error").Parse(c.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url template: %w", err)
+		}
+		sel.urlTmpl = t
+	}
+
+	for name, pattern := range c.Headers {
+		t, err := template.New(name).Option("missingkey=error").Parse(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header template for %s: %w", name, err)
+		}
+		sel.headerTmpls[name] = t
+	}
+
+	return sel, nil
+}
+
+// selectorData builds the root object templates execute against, so
+// patterns read as "{{.fields.tenant}}" / "{{.meta.index}}".
+func selectorData(event *publisher.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"fields": event.Content.Fields,
+		"meta":   event.Content.Meta,
+	}
+}
+
+// resolveURL renders the url template for event, falling back to the
+// output's first non-templated load-balanced endpoint when a referenced field is
+// missing. Returns "" only when the selector has no url template at all
+// (pure headers templating), in which case the caller routes through the
+// load balancer instead and the url is irrelevant.
+func (s *eventSelector) resolveURL(event *publisher.Event) string {
+	if s == nil || s.urlTmpl == nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := s.urlTmpl.Execute(&buf, selectorData(event)); err != nil {
+		return s.fallbackURL
+	}
+	return buf.String()
+}
+
+// resolveHeaders renders the configured header templates for event,
+// skipping any whose referenced field is missing.
+func (s *eventSelector) resolveHeaders(event *publisher.Event) map[string]string {
+	if s == nil || len(s.headerTmpls) == 0 {
+		return nil
+	}
+
+	data := selectorData(event)
+	headers := make(map[string]string, len(s.headerTmpls))
+	for name, tmpl := range s.headerTmpls {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			continue
+		}
+		headers[name] = buf.String()
+	}
+	return headers
+}
+
+// usesURLTemplate reports whether the url itself is templated, meaning
+// resolveURL picks the actual destination rather than "" (headers-only
+// routing, which stays on the load balancer's endpoints).
+func (s *eventSelector) usesURLTemplate() bool {
+	return s != nil && s.urlTmpl != nil
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+func TestNewEventSelectorNilWhenStatic(t *testing.T) {
+	sel, err := newEventSelector(config{URL: "http://a"}, []string{"http://a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sel != nil {
+		t.Fatalf("expected a nil selector for a fully static config")
+	}
+}
+
+func TestNewEventSelectorHeadersOnlyRequiresFallback(t *testing.T) {
+	// urls takes precedence over url (per resolveURLs), so a headers-only
+	// template with urls configured and url left empty must still resolve
+	// a usable fallback instead of silently landing on "".
+	sel, err := newEventSelector(config{Headers: map[string]string{"X-Index": "{{.fields.dataset}}"}}, []string{"http://a", "http://b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sel == nil {
+		t.Fatalf("expected a non-nil selector when headers are templated")
+	}
+	if sel.fallbackURL != "http://a" {
+		t.Fatalf("fallbackURL = %q, want the first resolved endpoint", sel.fallbackURL)
+	}
+	if sel.usesURLTemplate() {
+		t.Fatalf("a headers-only config should not use a url template")
+	}
+}
+
+func TestNewEventSelectorRejectsUnusableFallback(t *testing.T) {
+	if _, err := newEventSelector(config{Headers: map[string]string{"X-Index": "{{.fields.dataset}}"}}, nil); err == nil {
+		t.Fatalf("expected an error when dynamic routing has no url/urls to fall back to")
+	}
+}
+
+func TestEventSelectorResolveURLFallsBackOnMissingField(t *testing.T) {
+	sel, err := newEventSelector(config{URL: "https://collector/{{.fields.tenant}}/ingest"}, []string{"https://collector/{{.fields.tenant}}/ingest", "https://collector-fallback/ingest"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	event := &publisher.Event{Content: beat.Event{Fields: common.MapStr{"tenant": "acme"}}}
+	if got, want := sel.resolveURL(event), "https://collector/acme/ingest"; got != want {
+		t.Fatalf("resolveURL = %q, want %q", got, want)
+	}
+
+	missing := &publisher.Event{Content: beat.Event{Fields: common.MapStr{}}}
+	got := sel.resolveURL(missing)
+	if strings.Contains(got, "{{") {
+		t.Fatalf("resolveURL with a missing field = %q, fallback must not be an unrendered template", got)
+	}
+	if want := "https://collector-fallback/ingest"; got != want {
+		t.Fatalf("resolveURL with a missing field = %q, want the fallback %q", got, want)
+	}
+}
+
+func TestNewEventSelectorRejectsTemplatedURLWithNoSeparateFallback(t *testing.T) {
+	// A bare templated url with no separate urls: pool has nothing
+	// non-templated to fall back to — resolveURLs(c) just echoes the
+	// same template string back, which is not a usable destination.
+	if _, err := newEventSelector(config{URL: "https://collector/{{.fields.tenant}}/ingest"}, []string{"https://collector/{{.fields.tenant}}/ingest"}); err == nil {
+		t.Fatalf("expected an error when the only url is itself templated with no separate urls: pool")
+	}
+}
+
+func TestEventSelectorResolveHeadersSkipsMissingField(t *testing.T) {
+	sel, err := newEventSelector(config{Headers: map[string]string{
+		"X-Index": "{{.fields.dataset}}",
+	}}, []string{"http://a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	event := &publisher.Event{Content: beat.Event{Fields: common.MapStr{}}}
+	headers := sel.resolveHeaders(event)
+	if _, ok := headers["X-Index"]; ok {
+		t.Fatalf("header referencing a missing field should be omitted, got %v", headers)
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+	"golang.org/x/net/http2"
+)
+
+// buildTransport assembles the http.Transport for the configured URL, TLS,
+// proxy and HTTP/2 settings.
+func buildTransport(c config) (*http.Transport, error) {
+	tr := &http.Transport{
+		MaxIdleConns:          c.MaxIdleConns,
+		ResponseHeaderTimeout: time.Duration(c.ResponseHeaderTimeout) * time.Millisecond,
+		IdleConnTimeout:       time.Duration(c.IdleConnTimeout) * time.Second,
+		DisableCompression:    !c.Compression,
+		DisableKeepAlives:     !c.KeepAlive,
+		DialContext: func(ctx context.Context, network string, addr string) (conn net.Conn, err error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := dnsCache.LookupHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				var dialer net.Dialer
+				conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+				if err == nil {
+					break
+				}
+			}
+			return
+		},
+	}
+
+	tlsConfig, err := tlscommon.LoadTLSConfig(c.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tls config: %w", err)
+	}
+	if tlsConfig != nil {
+		// With a single endpoint, pin the ServerName for SNI. With several,
+		// leave it empty so net/http derives it per-connection from each
+		// request's host.
+		host := ""
+		urls, urlErr := resolveURLs(c)
+		if urlErr == nil && len(urls) == 1 {
+			if u, err := url.Parse(urls[0]); err == nil {
+				host = u.Hostname()
+			}
+		}
+		tr.TLSClientConfig = tlsConfig.BuildModuleClientConfig(host)
+	}
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		tr.Proxy = http.ProxyURL(proxyURL)
+
+		if len(c.ProxyHeaders) > 0 {
+			header := make(http.Header, len(c.ProxyHeaders))
+			for k, v := range c.ProxyHeaders {
+				header.Set(k, v)
+			}
+			tr.ProxyConnectHeader = header
+		}
+	}
+
+	if c.HTTP2 {
+		if err := http2.ConfigureTransport(tr); err != nil {
+			return nil, fmt.Errorf("configuring http2: %w", err)
+		}
+	}
+
+	return tr, nil
+}